@@ -0,0 +1,205 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TaskFunc is a Task that can observe cancellation and report failure:
+// it receives the ctx passed to RunContext/RunConcContext (itself
+// wrapped in a per-task timeout if Program.TaskTimeout is set) and
+// returns an error alongside its result.
+type TaskFunc func(ctx context.Context) (interface{}, error)
+
+// indexedTaskFunc pairs a TaskFunc with its position in the task list,
+// mirroring indexedTask for the context-aware worker pool.
+type indexedTaskFunc struct {
+	index int
+	task  TaskFunc
+}
+
+// taskFuncs returns the TaskFuncs to run: p.TaskFuncs itself if set,
+// otherwise p.Tasks adapted to the TaskFunc signature so the
+// context-aware methods also work for Programs that only set Tasks.
+func (p *Program) taskFuncs() []TaskFunc {
+	if p.TaskFuncs != nil {
+		return p.TaskFuncs
+	}
+
+	funcs := make([]TaskFunc, len(p.Tasks))
+	for i, t := range p.Tasks {
+		funcs[i] = adaptTask(t)
+	}
+	return funcs
+}
+
+// adaptTask wraps a legacy Task (which can't be canceled or fail) into
+// a TaskFunc so it can run through the context-aware path unchanged.
+func adaptTask(t Task) TaskFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		return t(), nil
+	}
+}
+
+/*
+RunContext runs a *Program sequentially like Run, but stops dispatching
+new tasks as soon as ctx is canceled and returns the results gathered
+so far alongside ctx.Err(). If Program.TaskTimeout is set, each task
+gets its own context.WithTimeout child derived from ctx; a task that
+overruns it has its slot filled with the timeout's error (typically
+context.DeadlineExceeded) and, depending on Program.StopOnError, either
+aborts the run or lets it continue with the next task. If Program.Retry
+is set, a task gets another try (with backoff) before its error is
+treated as final; see RetryPolicy.
+*/
+func (p *Program) RunContext(ctx context.Context) (results []interface{}, err error) {
+	if p.OnStart != nil {
+		p.OnStart()
+	}
+	defer func() {
+		if p.OnFinish != nil {
+			p.OnFinish(results)
+		}
+	}()
+
+	funcs := p.taskFuncs()
+	l := len(funcs)
+	results = make([]interface{}, l)
+
+	for i, f := range funcs {
+		select {
+		case <-ctx.Done():
+			return results, ctx.Err()
+		default:
+		}
+
+		v, taskErr := p.runWithRetry(ctx, i, f)
+		if taskErr != nil {
+			results[i] = taskErr
+		} else {
+			results[i] = v
+		}
+
+		if taskErr != nil && p.StopOnError {
+			return results, taskErr
+		}
+
+		if p.Interval > 0 && i != l-1 {
+			time.Sleep(p.Interval)
+		}
+	}
+
+	return results, nil
+}
+
+/*
+RunConcContext is the context-aware counterpart of RunConc: it
+dispatches tasks to a pool of `n` worker goroutines through the same
+channel-fed design, but stops feeding new tasks once ctx is canceled
+and returns the results gathered so far alongside ctx.Err(). A task
+error is handled like in RunContext via Program.TaskTimeout/StopOnError,
+except that StopOnError cancels a Program-local derived context shared
+by all workers, so the other workers wind down too instead of draining
+the remaining queue.
+*/
+func (p *Program) RunConcContext(ctx context.Context, n int) (results []interface{}, err error) {
+	if p.OnStart != nil {
+		p.OnStart()
+	}
+	defer func() {
+		if p.OnFinish != nil {
+			p.OnFinish(results)
+		}
+	}()
+
+	funcs := p.taskFuncs()
+	length := len(funcs)
+	runners := safeRunnerQuantity(n, length)
+	results = make([]interface{}, length)
+
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	tasks := make(chan indexedTaskFunc)
+	go func() {
+		defer close(tasks)
+		for i, f := range funcs {
+			select {
+			case <-runCtx.Done():
+				return
+			case tasks <- indexedTaskFunc{i, f}:
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(runners)
+	for i := 0; i < runners; i++ {
+		go func() {
+			defer wg.Done()
+
+			for it := range tasks {
+				v, taskErr := p.runWithRetry(runCtx, it.index, it.task)
+				if taskErr != nil {
+					results[it.index] = taskErr
+				} else {
+					results[it.index] = v
+				}
+
+				if taskErr != nil && p.StopOnError {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = taskErr
+					}
+					mu.Unlock()
+					cancel()
+				}
+
+				if p.Interval > 0 {
+					time.Sleep(p.Interval)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return results, ctxErr
+	}
+	return results, firstErr
+}
+
+// runTask invokes f, bounding it with Program.TaskTimeout if set. On
+// timeout it returns (nil, ctx.Err()) without waiting for f to return;
+// f is expected to observe ctx and stop on its own.
+func (p *Program) runTask(ctx context.Context, f TaskFunc) (interface{}, error) {
+	if p.TaskTimeout <= 0 {
+		return f(ctx)
+	}
+
+	taskCtx, cancel := context.WithTimeout(ctx, p.TaskTimeout)
+	defer cancel()
+
+	type outcome struct {
+		v   interface{}
+		err error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		v, err := f(taskCtx)
+		done <- outcome{v, err}
+	}()
+
+	select {
+	case o := <-done:
+		return o.v, o.err
+	case <-taskCtx.Done():
+		return nil, taskCtx.Err()
+	}
+}
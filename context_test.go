@@ -0,0 +1,116 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunContextResults(t *testing.T) {
+	p := Program{Tasks: tasks(5)}
+	res, err := p.RunContext(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expect := []interface{}{"task0", "task1", "task2", "task3", "task4"}
+	if !reflect.DeepEqual(expect, res) {
+		t.Errorf("expected %v\ngot %v\n", expect, res)
+	}
+}
+
+func TestRunContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := Program{
+		TaskFuncs: []TaskFunc{
+			func(ctx context.Context) (interface{}, error) { return "a", nil },
+			func(ctx context.Context) (interface{}, error) { cancel(); return "b", nil },
+			func(ctx context.Context) (interface{}, error) { return "c", nil },
+		},
+	}
+
+	res, err := p.RunContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if res[0] != "a" || res[1] != "b" || res[2] != nil {
+		t.Errorf("expected partial results [a b <nil>], got %v", res)
+	}
+}
+
+func TestRunContextTaskTimeout(t *testing.T) {
+	p := Program{
+		TaskTimeout: 10 * time.Millisecond,
+		TaskFuncs: []TaskFunc{
+			func(ctx context.Context) (interface{}, error) {
+				select {
+				case <-time.After(50 * time.Millisecond):
+					return "late", nil
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+			},
+			func(ctx context.Context) (interface{}, error) { return "fast", nil },
+		},
+	}
+
+	res, err := p.RunContext(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(res[0].(error), context.DeadlineExceeded) {
+		t.Errorf("expected slot 0 to hold a deadline-exceeded error, got %v", res[0])
+	}
+	if res[1] != "fast" {
+		t.Errorf("expected slot 1 to hold %q, got %v", "fast", res[1])
+	}
+}
+
+func TestRunContextStopOnError(t *testing.T) {
+	boom := errors.New("boom")
+
+	p := Program{
+		StopOnError: true,
+		TaskFuncs: []TaskFunc{
+			func(ctx context.Context) (interface{}, error) { return "a", nil },
+			func(ctx context.Context) (interface{}, error) { return nil, boom },
+			func(ctx context.Context) (interface{}, error) { return "c", nil },
+		},
+	}
+
+	res, err := p.RunContext(context.Background())
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected %v, got %v", boom, err)
+	}
+	if res[2] != nil {
+		t.Errorf("expected task after the error to not run, got %v", res[2])
+	}
+}
+
+func TestRunConcContextResults(t *testing.T) {
+	p := Program{Tasks: tasks(10)}
+	res, err := p.RunConcContext(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if res[i] != tasks(10)[i]() {
+			t.Errorf("result %d out of order: got %v", i, res[i])
+		}
+	}
+}
+
+func TestRunConcContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := Program{Tasks: tasks(10)}
+	_, err := p.RunConcContext(ctx, 3)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
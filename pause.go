@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// pauseState holds the Pause/Resume/Progress bookkeeping for a
+// Program. It's embedded by value so Program stays usable as a
+// struct literal; initCond lazily wires the sync.Cond to its mutex
+// the first time pause state is touched.
+type pauseState struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	once   sync.Once
+	paused bool
+
+	completed atomic.Int64
+	total     atomic.Int64
+}
+
+func (s *pauseState) initCond() {
+	s.once.Do(func() {
+		s.cond = sync.NewCond(&s.mu)
+	})
+}
+
+// reset prepares the counters for a fresh Run/RunConc call.
+func (s *pauseState) reset(total int) {
+	s.initCond()
+	s.completed.Store(0)
+	s.total.Store(int64(total))
+}
+
+// waitIfPaused blocks the calling goroutine while the Program is
+// paused. Called right before a task runs, from both p.run and the
+// RunConc worker loop.
+func (s *pauseState) waitIfPaused() {
+	s.initCond()
+
+	s.mu.Lock()
+	for s.paused {
+		s.cond.Wait()
+	}
+	s.mu.Unlock()
+}
+
+// taskDone records that one more task has finished, for Progress.
+func (s *pauseState) taskDone() {
+	s.completed.Add(1)
+}
+
+// Pause stops a running *Program before its next Task: in-flight
+// tasks finish, but no further ones start until Resume is called.
+func (p *Program) Pause() {
+	p.initCond()
+
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+}
+
+// Resume lets a paused *Program continue with its next Task.
+func (p *Program) Resume() {
+	p.initCond()
+
+	p.mu.Lock()
+	p.paused = false
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+// Progress reports how many of the current (or most recent) run's
+// tasks have completed out of its total, and is safe to call from any
+// goroutine while Run/RunConc is in flight.
+func (p *Program) Progress() (done, total int) {
+	return int(p.completed.Load()), int(p.total.Load())
+}
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauseResume(t *testing.T) {
+	p := &Program{
+		Tasks:    tasks(20),
+		Interval: 50 * time.Millisecond,
+	}
+
+	done := make(chan []interface{})
+	go func() { done <- p.Run() }()
+
+	time.Sleep(120 * time.Millisecond)
+	p.Pause()
+
+	doneBefore, _ := p.Progress()
+	time.Sleep(150 * time.Millisecond)
+	doneAfter, total := p.Progress()
+
+	if doneAfter != doneBefore {
+		t.Errorf("expected Progress to stay at %d while paused, got %d", doneBefore, doneAfter)
+	}
+	if total != 20 {
+		t.Errorf("expected total 20, got %d", total)
+	}
+
+	p.Resume()
+
+	select {
+	case res := <-done:
+		if len(res) != 20 {
+			t.Errorf("expected 20 results, got %d", len(res))
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Run did not complete after Resume")
+	}
+
+	if d, total := p.Progress(); d != total {
+		t.Errorf("expected Progress %d/%d to be complete", d, total)
+	}
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+/*
+RetryPolicy configures how RunContext/RunConcContext retry a TaskFunc
+that returns a non-nil error.
+
+MaxAttempts caps the total number of tries (the first try plus
+retries). Between tries, the runner sleeps for
+`min(MaxBackoff, InitialBackoff * Multiplier^(attempt-1))`, where
+attempt is the 1-indexed try that just failed (so the first retry
+waits InitialBackoff), perturbed by ±Jitter (a fraction of that delay, e.g. 0.1 for ±10%). ShouldRetry, if
+set, can veto a retry the policy would otherwise allow (attempt is the
+try that just failed, 1-indexed; v and err are its result).
+*/
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	Jitter         float64
+	ShouldRetry    func(attempt int, v interface{}, err error) bool
+}
+
+// backoff returns the delay before retrying after the attempt'th try
+// (1-indexed) failed.
+func (r *RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(r.InitialBackoff) * math.Pow(r.Multiplier, float64(attempt-1))
+	if r.MaxBackoff > 0 && d > float64(r.MaxBackoff) {
+		d = float64(r.MaxBackoff)
+	}
+
+	if r.Jitter > 0 {
+		d += (rand.Float64()*2 - 1) * d * r.Jitter
+		if d < 0 {
+			d = 0
+		}
+	}
+
+	return time.Duration(d)
+}
+
+// allow reports whether the attempt'th try (1-indexed), which just
+// failed with (v, err), should be retried.
+func (r *RetryPolicy) allow(attempt int, v interface{}, err error) bool {
+	if attempt >= r.MaxAttempts {
+		return false
+	}
+	if r.ShouldRetry != nil {
+		return r.ShouldRetry(attempt, v, err)
+	}
+	return true
+}
+
+/*
+runWithRetry runs f, retrying it per Program.Retry as long as it keeps
+returning an error. PreHook/PostHook fire once per try with the
+0-indexed attempt number, so a retry is distinguishable from the
+original try. Retries happen synchronously in the calling goroutine,
+so in RunConcContext a retried task stays on the same worker instead
+of going back through the dispatch channel.
+*/
+func (p *Program) runWithRetry(ctx context.Context, i int, f TaskFunc) (interface{}, error) {
+	for attempt := 0; ; attempt++ {
+		if p.PreHook != nil {
+			p.PreHook(i, attempt)
+		}
+
+		v, err := p.runTask(ctx, f)
+
+		if p.PostHook != nil {
+			p.PostHook(i, attempt, v, err)
+		}
+
+		if err == nil || p.Retry == nil || !p.Retry.allow(attempt+1, v, err) {
+			return v, err
+		}
+
+		select {
+		case <-time.After(p.Retry.backoff(attempt + 1)):
+		case <-ctx.Done():
+			return v, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyTask returns a TaskFunc that fails failures times before
+// succeeding with v, and counts its own attempts via calls.
+func flakyTask(failures int, v interface{}) (task TaskFunc, calls *atomic.Int64) {
+	calls = &atomic.Int64{}
+	boom := errors.New("flaky: not yet")
+
+	task = func(ctx context.Context) (interface{}, error) {
+		n := calls.Add(1)
+		if int(n) <= failures {
+			return nil, boom
+		}
+		return v, nil
+	}
+	return task, calls
+}
+
+func TestRunContextRetrySucceedsAfterFailures(t *testing.T) {
+	task, calls := flakyTask(2, "ok")
+
+	p := Program{
+		TaskFuncs: []TaskFunc{task},
+		Retry: &RetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: 5 * time.Millisecond,
+			MaxBackoff:     20 * time.Millisecond,
+			Multiplier:     2,
+		},
+	}
+
+	start := time.Now()
+	res, err := p.RunContext(context.Background())
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res[0] != "ok" {
+		t.Errorf("expected %q, got %v", "ok", res[0])
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts (2 failures + 1 success), got %d", calls.Load())
+	}
+
+	// Two retries: backoff(1)=5ms, backoff(2)=10ms, so the run should
+	// take at least ~15ms and comfortably less than a second.
+	if elapsed < 15*time.Millisecond {
+		t.Errorf("expected at least the backoff envelope to elapse, got %v", elapsed)
+	}
+	if elapsed > time.Second {
+		t.Errorf("expected the run to finish well within a second, took %v", elapsed)
+	}
+}
+
+func TestRunContextRetryExhausted(t *testing.T) {
+	task, calls := flakyTask(10, "ok")
+
+	p := Program{
+		TaskFuncs: []TaskFunc{task},
+		Retry: &RetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+		},
+	}
+
+	res, err := p.RunContext(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected run-level error: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected exactly MaxAttempts=3 calls, got %d", calls.Load())
+	}
+	if _, ok := res[0].(error); !ok {
+		t.Errorf("expected the exhausted task's slot to hold its final error, got %v", res[0])
+	}
+}
+
+func TestRunContextRetryAttemptHooks(t *testing.T) {
+	task, _ := flakyTask(2, "ok")
+	var preAttempts, postAttempts []int
+
+	p := Program{
+		TaskFuncs: []TaskFunc{task},
+		Retry: &RetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+		},
+		PreHook:  func(i, attempt int) { preAttempts = append(preAttempts, attempt) },
+		PostHook: func(i, attempt int, v interface{}, err error) { postAttempts = append(postAttempts, attempt) },
+	}
+
+	if _, err := p.RunContext(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expect := []int{0, 1, 2}
+	if len(preAttempts) != len(expect) || len(postAttempts) != len(expect) {
+		t.Fatalf("expected attempts %v, got pre=%v post=%v", expect, preAttempts, postAttempts)
+	}
+	for i, a := range expect {
+		if preAttempts[i] != a || postAttempts[i] != a {
+			t.Errorf("expected attempt %d at index %d, got pre=%d post=%d", a, i, preAttempts[i], postAttempts[i])
+		}
+	}
+}
+
+func TestRunConcContextRetrySameWorker(t *testing.T) {
+	task, calls := flakyTask(2, "ok")
+
+	p := Program{
+		TaskFuncs: []TaskFunc{task},
+		Retry: &RetryPolicy{
+			MaxAttempts:    5,
+			InitialBackoff: time.Millisecond,
+		},
+	}
+
+	res, err := p.RunConcContext(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res[0] != "ok" {
+		t.Errorf("expected %q, got %v", "ok", res[0])
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
@@ -5,43 +5,90 @@ It features two main methods: `*Program.Run()` and `*Program.RunConc(n int)`. Th
 package runner
 
 import (
-	"sort"
+	"context"
+	"sync"
 	"time"
 )
 
 /*
 Program represents the task list to be run with its options.
 
-	- `Tasks` (**Required**): a slice of Task, the functions to be executed
+	- `Tasks` (**Required** for Run/RunConc, and for RunContext/
+	RunConcContext unless `TaskFuncs` is set): a slice of Task, the
+	functions to be executed
+
+	- `TaskFuncs`: a slice of TaskFunc, used by RunContext/RunConcContext
+	instead of `Tasks` when set. Lets a task observe ctx cancellation and
+	return an error instead of just a value.
 
 	- `Interval`: time between two Task.
 
-	- `PreHook`: callback executed before each Task (`i`: current Task index).
+	- `TaskTimeout`: used by RunContext/RunConcContext only. If set, each
+	task gets its own `context.WithTimeout` child of the run's ctx; a task
+	that overruns it has its slot filled with the timeout error.
+
+	- `StopOnError`: used by RunContext/RunConcContext only. If a task
+	returns a non-nil error, abort the run instead of continuing with the
+	remaining tasks.
+
+	- `Retry`: used by RunContext/RunConcContext only. If set, a task that
+	returns an error is retried with exponential backoff instead of its
+	error being treated as final; see RetryPolicy.
+
+	- `Trace`: if true (or if a runtime/trace trace is already being
+	recorded), Run/RunConc emit a "runner.Program" user task and wrap each
+	Task in a region, so `go tool trace` can show dispatch skew and
+	per-task latency.
+
+	- `TaskLabel`: names the trace region for Task `i`; defaults to
+	"task<i>" when unset.
+
+	- `PreHook`: callback executed before each Task (`i`: current Task index,
+		`attempt`: 0 for the first try, incrementing on each Retry retry;
+		always 0 for Run/RunConc, which never retry).
 
-	-`PostHook`: callback executed after each Task (`i`: current Task index,
-		`v`: current Task returned value).
+	-`PostHook`: callback executed after each Task (`i`, `attempt`: as for
+		PreHook, `v`: current Task returned value, `err`: non-nil if the
+		task errored; always nil for Run/RunConc, since Task itself can't
+		return one).
 
 	-`OnStart`: callback executed before the program starts.
 
 	-`OnFinish`: callback executed after the program ends (v = slice of all
 	task results)
+
+Pause, Resume and Progress let another goroutine (e.g. a UI or HTTP
+status handler) control and observe a Run/RunConc in flight: Pause
+blocks the next Task from starting until Resume is called, and
+Progress reports how many of the current run's tasks have completed.
 */
 type Program struct {
-	Tasks    []Task
-	Interval time.Duration
-	PreHook  func(i int)
-	PostHook func(i int, v interface{})
-	OnStart  func()
-	OnFinish func(v []interface{})
-	isConc   bool
+	Tasks       []Task
+	TaskFuncs   []TaskFunc
+	Interval    time.Duration
+	TaskTimeout time.Duration
+	StopOnError bool
+	Retry       *RetryPolicy
+	Trace       bool
+	TaskLabel   func(i int) string
+	PreHook     func(i, attempt int)
+	PostHook    func(i, attempt int, v interface{}, err error)
+	OnStart     func()
+	OnFinish    func(v []interface{})
+	isConc      bool
+
+	pauseState
 }
 
 // Task is a function run by *FuncList.Run().
 type Task func() interface{}
 
-type trackedResult struct {
-	id  int
-	res []interface{}
+// indexedTask pairs a Task with its position in Program.Tasks, so a
+// worker in RunConc's pool can write its result straight to the right
+// slot without needing to know which other tasks it was handed.
+type indexedTask struct {
+	index int
+	task  Task
 }
 
 // Run runs a *Program sequentially, executing all specified callbacks.
@@ -58,28 +105,29 @@ func (p *Program) Run() (results []interface{}) {
 		}
 	}()
 
-	results = p.run(p.Tasks, 0)
+	ctx, end := p.traceProgram(context.Background())
+	defer end()
+
+	p.reset(len(p.Tasks))
+
+	results = p.run(ctx, p.Tasks, 0)
 	return
 }
 
 /*
 RunConc runs a *Program concurrently, dispatching its tasks among `n` runners
-(goroutines), and return a slice of each Task return value.
+(goroutines), and returns a slice of each Task return value in the original
+order.
 
 Details:
 
-Each runner is allocated a range (a subslice of the original
-Task slice) which length is calculated upon the total length and the number
-of runners. For instance, for 10 Task and 3 runners:
-run0[0:3] run1[3:6] run2[6:10].
-
-Then each subslice is run concurrently and their result stored in a channel
-that also contain the runner ID. This is necessary when gathering the results,
-because they don't necessarily return in the correct order. Associating a
-runner ID to a set of results allows to re-order them properly.
-
-The process of retrieving results can largely be optimized as there are
-many consecutive loops and sorting operations.
+Tasks are fed one at a time, paired with their original index, onto a
+single channel that `n` worker goroutines range over. Each worker writes
+its result straight to `results[index]`, so results come back in the
+right order without needing to sort anything afterwards. This also means
+a worker that picks up slow tasks doesn't starve the others: whichever
+worker is free next takes the next task, so heterogeneous task durations
+balance out naturally instead of skewing a fixed up-front split.
 */
 func (p *Program) RunConc(n int) (results []interface{}) {
 	// In case run() method needs to know whether
@@ -97,65 +145,75 @@ func (p *Program) RunConc(n int) (results []interface{}) {
 		}
 	}()
 
+	ctx, end := p.traceProgram(context.Background())
+	defer end()
+
 	length := len(p.Tasks)
 	runners := safeRunnerQuantity(n, length)
-	span := length / runners
-	rawPartial := make(chan trackedResult, runners)
+	results = make([]interface{}, length)
+	p.reset(length)
 
-	// Dispatch tasks into zones for each runner (n)
-	// and run a goroutine for each zone
-	for i := 0; i < runners; i++ {
-		isLastRunner := i == runners-1
-		start := i * span
-		end := start + span
-		// Last runner goes to the end
-		if isLastRunner {
-			end = length
+	tasks := make(chan indexedTask)
+	go func() {
+		for i, t := range p.Tasks {
+			tasks <- indexedTask{i, t}
 		}
-		part := p.Tasks[start:end]
-
-		// Run concurrently. Variable i is used to keep track of the runner
-		// in order to sort the final slice in the correct order.
-		go func(i int) {
-			rawPartial <- trackedResult{i, p.run(part, i)}
-		}(i)
-	}
+		close(tasks)
+	}()
 
-	// Gather results
-	var rawResults []trackedResult
+	var wg sync.WaitGroup
+	wg.Add(runners)
 	for i := 0; i < runners; i++ {
-		rawResults = append(rawResults, <-rawPartial)
-	}
+		go func(workerID int) {
+			defer wg.Done()
 
-	// Sort results in the correct order using runner id
-	sort.SliceStable(rawResults, func(i, j int) bool {
-		return rawResults[i].id < rawResults[j].id
-	})
+			for it := range tasks {
+				p.waitIfPaused()
 
-	// Remove ids from results
-	for _, v := range rawResults {
-		results = append(results, v.res...)
+				if p.PreHook != nil {
+					p.PreHook(it.index, 0)
+				}
+
+				p.logWorker(ctx, workerID, it.index)
+				v := p.execute(ctx, it.index, it.task)
+				results[it.index] = v
+				p.taskDone()
+
+				if p.PostHook != nil {
+					p.PostHook(it.index, 0, v, nil)
+				}
+
+				if p.Interval > 0 {
+					time.Sleep(p.Interval)
+				}
+			}
+		}(i)
 	}
+	wg.Wait()
 
 	return
 }
 
-func (p *Program) run(tasks []Task, offset int) []interface{} {
+func (p *Program) run(ctx context.Context, tasks []Task, offset int) []interface{} {
 	l := len(tasks)
 	results := make([]interface{}, l)
 
 	for i, f := range tasks {
 		isLast := i == l-1
+		idx := i + offset
+
+		p.waitIfPaused()
 
 		if p.PreHook != nil {
-			p.PreHook(i + offset)
+			p.PreHook(idx, 0)
 		}
 
-		v := f()
+		v := p.execute(ctx, idx, f)
 		results[i] = v
+		p.taskDone()
 
 		if p.PostHook != nil {
-			p.PostHook(i+offset, v)
+			p.PostHook(idx, 0, v, nil)
 		}
 
 		if p.Interval > 0 && !isLast {
@@ -1,10 +1,12 @@
 package runner
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math/rand"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 )
@@ -12,8 +14,8 @@ import (
 var example = Program{
 	Tasks:    tasks(3),
 	Interval: 1 * time.Second,
-	PreHook:  func(i int) { log.Printf("Starting task %d...\n", i) },
-	PostHook: func(i int, v interface{}) { log.Printf("Task %d done. Output: %v\n", i, v) },
+	PreHook:  func(i, attempt int) { log.Printf("Starting task %d...\n", i) },
+	PostHook: func(i, attempt int, v interface{}, err error) { log.Printf("Task %d done. Output: %v\n", i, v) },
 	OnStart:  func() { log.Println("Starting program.") },
 	OnFinish: func(v []interface{}) { log.Printf("Program over. Final output: %v\n", v) },
 }
@@ -44,8 +46,8 @@ func TestHooks(t *testing.T) {
 		"OnFinish": false,
 	}
 
-	preHook := func(i int) { res["PreHook"] = true }
-	postHook := func(i int, v interface{}) { res["PostHook"] = true }
+	preHook := func(i, attempt int) { res["PreHook"] = true }
+	postHook := func(i, attempt int, v interface{}, err error) { res["PostHook"] = true }
 	onStart := func() { res["OnStart"] = true }
 	onFinish := func(v []interface{}) { res["OnFinish"] = true }
 
@@ -108,6 +110,92 @@ func BenchmarkRunConc(b *testing.B) {
 	p.RunConc(8)
 }
 
+// BenchmarkRunConcImbalanced compares the current channel-fed worker
+// pool against the pre-slice-and-sort approach it replaced, on a
+// workload where task durations vary wildly. The old approach
+// load-imbalances because a runner stuck with a contiguous span of
+// slow tasks can't hand any of them off to an idle runner.
+func BenchmarkRunConcImbalanced(b *testing.B) {
+	b.Run("old", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := &Program{Tasks: mixedTasks(200)}
+			oldRunConc(p, 8)
+		}
+	})
+
+	b.Run("new", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			p := &Program{Tasks: mixedTasks(200)}
+			p.RunConc(8)
+		}
+	})
+}
+
+// mixedTasks returns n Tasks where the first tenth sleep for a few
+// milliseconds and the rest return almost immediately, simulating a
+// heterogeneous workload that a fixed up-front contiguous split
+// handles poorly: every slow task lands in the same runner's span,
+// so that one runner is still working long after the others, idle,
+// have drained their all-fast spans.
+func mixedTasks(n int) (ts []Task) {
+	slow := n / 10
+	for i := 0; i < n; i++ {
+		if i < slow {
+			ts = append(ts, func() interface{} {
+				time.Sleep(5 * time.Millisecond)
+				return "slow"
+			})
+			continue
+		}
+		ts = append(ts, func() interface{} { return "fast" })
+	}
+	return
+}
+
+// oldRunConc is the pre-slice-and-sort RunConc implementation kept
+// here only as a baseline for BenchmarkRunConcImbalanced; it's not
+// part of the public API any more.
+func oldRunConc(p *Program, n int) (results []interface{}) {
+	type trackedResult struct {
+		id  int
+		res []interface{}
+	}
+
+	length := len(p.Tasks)
+	runners := safeRunnerQuantity(n, length)
+	span := length / runners
+	rawPartial := make(chan trackedResult, runners)
+
+	for i := 0; i < runners; i++ {
+		isLastRunner := i == runners-1
+		start := i * span
+		end := start + span
+		if isLastRunner {
+			end = length
+		}
+		part := p.Tasks[start:end]
+
+		go func(i int) {
+			rawPartial <- trackedResult{i, p.run(context.Background(), part, i)}
+		}(i)
+	}
+
+	var rawResults []trackedResult
+	for i := 0; i < runners; i++ {
+		rawResults = append(rawResults, <-rawPartial)
+	}
+
+	sort.SliceStable(rawResults, func(i, j int) bool {
+		return rawResults[i].id < rawResults[j].id
+	})
+
+	for _, v := range rawResults {
+		results = append(results, v.res...)
+	}
+
+	return
+}
+
 // tasks returns a slice of n Tasks that return "task"+index, e.g. "task2".
 func tasks(n int) (ts []Task) {
 	for i := 0; i < n; i++ {
@@ -0,0 +1,310 @@
+package runner
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// Priority levels accepted by Scheduler.Submit.
+const (
+	LowPriority = iota
+	NormalPriority
+	HighPriority
+)
+
+// defaultWeights assigns each priority level its nominal share of
+// wall-clock time: a bucket with weight w should, over time, be
+// dispatched roughly w times as often as a weight-1 bucket. See
+// bucket.usage and Scheduler.pickBucket for how this is enforced.
+var defaultWeights = map[int]float64{
+	HighPriority:   4,
+	NormalPriority: 2,
+	LowPriority:    1,
+}
+
+/*
+Scheduler dispatches Tasks tagged with a priority level to a fixed pool
+of runner goroutines. Each priority level owns a min-heap of pending
+tasks keyed by submission order, so tasks within a bucket run FIFO. A
+dedicated scheduler goroutine picks the next bucket to drain using
+weighted fair queuing: each bucket tracks its own cumulative virtual
+time (wall time consumed so far, divided by its weight), and the
+bucket with the least virtual time runs next. Because virtual time
+advances more slowly for a higher-weight bucket, buckets interleave in
+rough proportion to their weights instead of one draining completely
+before the other ever runs, as a naive "lowest average duration"
+metric would do. Virtual time accumulates over the Scheduler's whole
+lifetime; it is not limited to a rolling window of recent tasks.
+
+Scheduler is aimed at longer-running tasks (roughly >=1ms); the heap
+and virtual-time bookkeeping overhead isn't worth it for short tasks, where
+Program.Run/RunConc remains the simpler and cheaper choice.
+
+PreHook, PostHook, OnStart and OnFinish mirror Program's semantics:
+OnStart fires once on Start, PreHook/PostHook fire around each Task
+(indexed by submission sequence), and OnFinish fires once all tasks
+submitted before Stop have completed, with their results in
+submission order.
+*/
+type Scheduler struct {
+	PreHook  func(i int)
+	PostHook func(i int, v interface{})
+	OnStart  func()
+	OnFinish func(v []interface{})
+
+	mu      sync.Mutex
+	buckets map[int]*bucket
+	seq     uint64
+
+	dispatch chan *schedTask
+	quit     chan struct{}
+	wg       sync.WaitGroup
+
+	resMu   sync.Mutex
+	results []interface{}
+}
+
+// schedTask is a Task submitted to a Scheduler, tagged with its
+// priority bucket and a global submission sequence used both as the
+// bucket heap's FIFO key and as the index into Scheduler.results.
+type schedTask struct {
+	seq      uint64
+	priority int
+	task     Task
+	out      chan interface{}
+}
+
+// bucket holds one priority level's pending tasks and its cumulative
+// virtual time for weighted fair queuing.
+type bucket struct {
+	priority int
+	weight   float64
+	pending  taskHeap
+
+	// usage is the bucket's cumulative virtual time: the sum, over
+	// every task it has run, of that task's duration divided by the
+	// bucket's weight. It only ever grows via record, and is shifted
+	// down uniformly across all buckets by Scheduler.pickBucket once
+	// it picks a winner, so only the relative differences between
+	// buckets (which is all fairness depends on) are meaningful.
+	usage float64
+}
+
+// record adds d's contribution to the bucket's virtual time: d divided
+// by the bucket's weight, so a higher-weight bucket's virtual time
+// advances more slowly for the same wall-clock duration and so gets
+// picked more often, in rough proportion to its weight.
+func (b *bucket) record(d time.Duration) {
+	b.usage += float64(d) / b.weight
+}
+
+// taskHeap is a container/heap of schedTask ordered by submission
+// sequence, giving FIFO order within a single priority bucket.
+type taskHeap []*schedTask
+
+func (h taskHeap) Len() int            { return len(h) }
+func (h taskHeap) Less(i, j int) bool  { return h[i].seq < h[j].seq }
+func (h taskHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap) Push(x interface{}) { *h = append(*h, x.(*schedTask)) }
+func (h *taskHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// Submit enqueues task into the bucket for priority and returns a
+// channel that receives its single result once run. priority is
+// typically one of LowPriority, NormalPriority or HighPriority, but
+// any int is accepted with a weight of 1 the first time it's seen.
+func (s *Scheduler) Submit(task Task, priority int) <-chan interface{} {
+	s.mu.Lock()
+	if s.buckets == nil {
+		s.buckets = make(map[int]*bucket)
+	}
+	b, ok := s.buckets[priority]
+	if !ok {
+		weight, ok := defaultWeights[priority]
+		if !ok {
+			weight = 1
+		}
+		b = &bucket{priority: priority, weight: weight}
+		s.buckets[priority] = b
+	}
+
+	s.seq++
+	st := &schedTask{seq: s.seq, priority: priority, task: task, out: make(chan interface{}, 1)}
+
+	// Add/growResults must happen before the task is pushed onto the
+	// bucket heap: once pushed and s.mu released, run()/work() can pick
+	// it up and call wg.Done()/write results[i] immediately, which would
+	// race the matching Add/grow below if they ran after unlocking.
+	s.wg.Add(1)
+	s.growResults(st.seq)
+
+	heap.Push(&b.pending, st)
+	s.mu.Unlock()
+
+	return st.out
+}
+
+// growResults grows Scheduler.results so index seq-1 is addressable.
+// Callers must hold s.mu.
+func (s *Scheduler) growResults(seq uint64) {
+	s.resMu.Lock()
+	for uint64(len(s.results)) < seq {
+		s.results = append(s.results, nil)
+	}
+	s.resMu.Unlock()
+}
+
+// Start launches the scheduler goroutine and a fixed pool of n runner
+// goroutines, then returns immediately; tasks submitted (before or
+// after Start) are drained until Stop is called.
+func (s *Scheduler) Start(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	s.mu.Lock()
+	if s.buckets == nil {
+		s.buckets = make(map[int]*bucket)
+	}
+	s.dispatch = make(chan *schedTask)
+	s.quit = make(chan struct{})
+	s.mu.Unlock()
+
+	if s.OnStart != nil {
+		s.OnStart()
+	}
+
+	go s.run()
+
+	for i := 0; i < n; i++ {
+		go s.work()
+	}
+}
+
+// Stop signals the scheduler to stop accepting further dispatch
+// rounds once every already-queued task has been handed to a worker,
+// waits for all of them to complete, then calls OnFinish with all
+// results collected so far in submission order.
+func (s *Scheduler) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+
+	if s.OnFinish != nil {
+		s.resMu.Lock()
+		v := append([]interface{}(nil), s.results...)
+		s.resMu.Unlock()
+		s.OnFinish(v)
+	}
+}
+
+// run is the scheduler goroutine: it repeatedly picks the
+// least-over-served non-empty bucket and feeds its next task to
+// s.dispatch for a worker to pick up. Once Stop has been called and
+// every bucket is empty, it closes s.dispatch so workers return.
+func (s *Scheduler) run() {
+	defer close(s.dispatch)
+
+	for {
+		s.mu.Lock()
+		b := s.pickBucket()
+		if b == nil {
+			s.mu.Unlock()
+
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		st := heap.Pop(&b.pending).(*schedTask)
+		s.mu.Unlock()
+
+		s.dispatch <- st
+	}
+}
+
+// pickBucket returns the non-empty bucket with the least cumulative
+// virtual time (see bucket.usage), i.e. the one that has fallen
+// furthest behind its weighted fair share of wall time so far. Before
+// returning, it shifts every bucket's usage down by the lowest usage
+// across all buckets (pending or not): virtual time only has to be
+// compared relatively, and normalizing like this keeps it from
+// growing without bound over a long-running scheduler. Callers must
+// hold s.mu.
+func (s *Scheduler) pickBucket() *bucket {
+	var best *bucket
+	var bestUsage float64
+
+	for _, b := range s.buckets {
+		if len(b.pending) == 0 {
+			continue
+		}
+
+		if best == nil || b.usage < bestUsage {
+			best = b
+			bestUsage = b.usage
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	floor := best.usage
+	for _, b := range s.buckets {
+		if b.usage < floor {
+			floor = b.usage
+		}
+	}
+	if floor > 0 {
+		for _, b := range s.buckets {
+			b.usage -= floor
+		}
+	}
+
+	return best
+}
+
+// work runs tasks handed to it on s.dispatch until run() closes the
+// channel, i.e. until Stop has been called and every queued task has
+// been dispatched.
+func (s *Scheduler) work() {
+	for st := range s.dispatch {
+		i := int(st.seq - 1)
+
+		if s.PreHook != nil {
+			s.PreHook(i)
+		}
+
+		start := time.Now()
+		v := st.task()
+		dur := time.Since(start)
+
+		s.mu.Lock()
+		if b, ok := s.buckets[st.priority]; ok {
+			b.record(dur)
+		}
+		s.mu.Unlock()
+
+		st.out <- v
+		close(st.out)
+
+		s.resMu.Lock()
+		s.results[i] = v
+		s.resMu.Unlock()
+
+		if s.PostHook != nil {
+			s.PostHook(i, v)
+		}
+
+		s.wg.Done()
+	}
+}
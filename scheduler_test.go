@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSchedulerHooks(t *testing.T) {
+	res := map[string]bool{
+		"PreHook":  false,
+		"PostHook": false,
+		"OnStart":  false,
+		"OnFinish": false,
+	}
+	var mu sync.Mutex
+
+	s := &Scheduler{
+		PreHook:  func(i int) { mu.Lock(); res["PreHook"] = true; mu.Unlock() },
+		PostHook: func(i int, v interface{}) { mu.Lock(); res["PostHook"] = true; mu.Unlock() },
+		OnStart:  func() { mu.Lock(); res["OnStart"] = true; mu.Unlock() },
+		OnFinish: func(v []interface{}) { mu.Lock(); res["OnFinish"] = true; mu.Unlock() },
+	}
+
+	s.Start(2)
+	for i := 0; i < 5; i++ {
+		<-s.Submit(func() interface{} { return "ok" }, NormalPriority)
+	}
+	s.Stop()
+
+	for hook, ok := range res {
+		if !ok {
+			t.Errorf("hook %s not called", hook)
+		}
+	}
+}
+
+func TestSchedulerResults(t *testing.T) {
+	s := &Scheduler{}
+	s.Start(3)
+
+	chs := make([]<-chan interface{}, 0, 6)
+	for i := 0; i < 3; i++ {
+		chs = append(chs, s.Submit(func() interface{} { return "high" }, HighPriority))
+	}
+	for i := 0; i < 3; i++ {
+		chs = append(chs, s.Submit(func() interface{} { return "low" }, LowPriority))
+	}
+
+	for _, ch := range chs {
+		if v := <-ch; v != "high" && v != "low" {
+			t.Errorf("unexpected result %v", v)
+		}
+	}
+
+	s.Stop()
+}
+
+// TestSchedulerFairness submits equal counts of High- and LowPriority
+// tasks of identical duration to a single-worker Scheduler (so dispatch
+// order is exactly pickBucket's order, with no worker-count noise) and
+// records completion order via PostHook. It checks two things a fully
+// priority-blind or a fully-drain-then-switch scheduler would each get
+// wrong: High should take roughly its weighted 4:1 share of the first n
+// completions, while both buckets still have work, AND the two
+// priorities should interleave, i.e.
+// neither one should ever run to completion before the other starts —
+// a long unbroken run of one priority means the other is being starved
+// rather than fairly rationed.
+func TestSchedulerFairness(t *testing.T) {
+	const n = 40
+	// maxRun bounds the longest run of consecutive same-priority
+	// completions. With a 4:1 weight ratio the scheduler legitimately
+	// dispatches High in bursts of around 4-8 between single Low tasks
+	// (loosely, to tolerate real-clock scheduling jitter in the
+	// measured durations feeding bucket.usage), but a bucket should
+	// never fully drain (a run anywhere near n) before the other gets
+	// a turn.
+	const maxRun = 16
+
+	s := &Scheduler{}
+
+	var mu sync.Mutex
+	var order []string
+	s.PostHook = func(i int, v interface{}) {
+		mu.Lock()
+		order = append(order, v.(string))
+		mu.Unlock()
+	}
+
+	s.Start(1)
+
+	chs := make([]<-chan interface{}, 0, n*2)
+	for i := 0; i < n; i++ {
+		chs = append(chs, s.Submit(func() interface{} {
+			time.Sleep(time.Millisecond)
+			return "high"
+		}, HighPriority))
+	}
+	for i := 0; i < n; i++ {
+		chs = append(chs, s.Submit(func() interface{} {
+			time.Sleep(time.Millisecond)
+			return "low"
+		}, LowPriority))
+	}
+
+	for _, ch := range chs {
+		<-ch
+	}
+
+	s.Stop()
+
+	if len(s.results) != n*2 {
+		t.Fatalf("expected %d results, got %d", n*2, len(s.results))
+	}
+
+	mu.Lock()
+	completed := append([]string(nil), order...)
+	mu.Unlock()
+
+	// Both buckets submit n tasks, so over all 2n completions High is
+	// always exactly half; only the first n completions, where both
+	// buckets still have work, show the weighting.
+	highCount := 0
+	for _, v := range completed[:n] {
+		if v == "high" {
+			highCount++
+		}
+	}
+
+	// Weight ratio is 4:1, so a correctly weighted scheduler should
+	// dispatch High well above its naive 50% share of the first n
+	// completions (about 80%); a priority-blind one would land at ~50%.
+	if highCount < n*3/2/2 {
+		t.Errorf("expected HighPriority to take roughly its 4:1 weighted share, got %d/%d high in the first %d completions", highCount, n, n)
+	}
+
+	// Once either bucket's n submissions have all completed, the other
+	// necessarily finishes out the rest alone — that tail isn't
+	// unfairness, it's just the other bucket running out of work. So
+	// bound the longest run only over the prefix where both buckets
+	// still had pending tasks left, which is where a full-drain-then-
+	// switch scheduler (strict priority in disguise) would give itself
+	// away with a run near n.
+	seenHigh, seenLow, longestRun, runLen := 0, 0, 0, 0
+	for i, v := range completed {
+		if v == "high" {
+			seenHigh++
+		} else {
+			seenLow++
+		}
+		if i > 0 && v == completed[i-1] {
+			runLen++
+		} else {
+			runLen = 1
+		}
+		if runLen > longestRun {
+			longestRun = runLen
+		}
+		if seenHigh == n || seenLow == n {
+			break
+		}
+	}
+	if longestRun > maxRun {
+		t.Errorf("expected no run of consecutive same-priority completions longer than %d while both buckets still had work, got %d", maxRun, longestRun)
+	}
+}
+
+// TestPickBucketPrefersHigherWeight checks pickBucket in isolation: given
+// two buckets that have consumed the same wall time, the one with the
+// higher weight must have the lower cumulative usage and so be picked,
+// since it's entitled to a larger share of wall time per task.
+func TestPickBucketPrefersHigherWeight(t *testing.T) {
+	high := &bucket{priority: HighPriority, weight: 4, pending: taskHeap{{seq: 1}}}
+	high.record(10 * time.Millisecond)
+
+	low := &bucket{priority: LowPriority, weight: 1, pending: taskHeap{{seq: 2}}}
+	low.record(10 * time.Millisecond)
+
+	s := &Scheduler{buckets: map[int]*bucket{HighPriority: high, LowPriority: low}}
+
+	if got := s.pickBucket(); got != high {
+		t.Errorf("expected the higher-weight bucket to be picked when wall time is tied, got priority %d", got.priority)
+	}
+}
+
+// TestPickBucketPrefersUnderservedBucket checks that a bucket which has
+// already consumed much more of its weighted share loses out to one that
+// hasn't, even though the underserved bucket has a lower nominal weight.
+func TestPickBucketPrefersUnderservedBucket(t *testing.T) {
+	overserved := &bucket{priority: HighPriority, weight: 4, pending: taskHeap{{seq: 1}}}
+	overserved.record(100 * time.Millisecond)
+
+	underserved := &bucket{priority: LowPriority, weight: 1, pending: taskHeap{{seq: 2}}}
+	underserved.record(1 * time.Millisecond)
+
+	s := &Scheduler{buckets: map[int]*bucket{HighPriority: overserved, LowPriority: underserved}}
+
+	if got := s.pickBucket(); got != underserved {
+		t.Errorf("expected the underserved bucket to be picked despite its lower weight, got priority %d", got.priority)
+	}
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"runtime/trace"
+)
+
+// traced reports whether Run/RunConc should emit runtime/trace user
+// tasks and regions: either the caller opted in explicitly via
+// Program.Trace, or a trace is already being recorded (trace.IsEnabled).
+func (p *Program) traced() bool {
+	return p.Trace || trace.IsEnabled()
+}
+
+// taskLabel returns the region name for Task i: Program.TaskLabel if
+// set, otherwise "task<i>".
+func (p *Program) taskLabel(i int) string {
+	if p.TaskLabel != nil {
+		return p.TaskLabel(i)
+	}
+	return fmt.Sprintf("task%d", i)
+}
+
+// traceProgram starts the "runner.Program" user task when tracing is
+// active and returns the ctx to thread through the run, plus a func to
+// end the task. When tracing is inactive it returns ctx unchanged and
+// a no-op func, so callers can use it unconditionally.
+func (p *Program) traceProgram(ctx context.Context) (context.Context, func()) {
+	if !p.traced() {
+		return ctx, func() {}
+	}
+
+	ctx, task := trace.NewTask(ctx, "runner.Program")
+	return ctx, task.End
+}
+
+// logWorker attaches a trace.Log entry recording which worker picked
+// up which task, when tracing is active. Together with the per-task
+// regions from execute, this is what lets `go tool trace`'s goroutine
+// analysis view show dispatch skew across RunConc's worker pool.
+func (p *Program) logWorker(ctx context.Context, workerID, i int) {
+	if !p.traced() {
+		return
+	}
+	trace.Logf(ctx, "runner", "worker=%d task=%d", workerID, i)
+}
+
+// execute runs f, wrapping it in a trace.WithRegion named after Task i
+// when tracing is active, so `go tool trace`'s user-task and goroutine
+// views show per-task latency without extra instrumentation from the
+// caller.
+func (p *Program) execute(ctx context.Context, i int, f func() interface{}) interface{} {
+	if !p.traced() {
+		return f()
+	}
+
+	var v interface{}
+	trace.WithRegion(ctx, p.taskLabel(i), func() {
+		v = f()
+	})
+	return v
+}
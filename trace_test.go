@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime/trace"
+	"testing"
+)
+
+func TestTaskLabelDefault(t *testing.T) {
+	p := &Program{}
+	if got := p.taskLabel(3); got != "task3" {
+		t.Errorf("expected %q, got %q", "task3", got)
+	}
+}
+
+func TestTaskLabelCustom(t *testing.T) {
+	p := &Program{TaskLabel: func(i int) string { return fmt.Sprintf("custom-%d", i) }}
+	if got := p.taskLabel(2); got != "custom-2" {
+		t.Errorf("expected %q, got %q", "custom-2", got)
+	}
+}
+
+func TestRunWithTraceRecording(t *testing.T) {
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Fatalf("trace.Start: %v", err)
+	}
+
+	p := &Program{Tasks: tasks(3)}
+	res := p.Run()
+	trace.Stop()
+
+	expect := []interface{}{"task0", "task1", "task2"}
+	if !reflect.DeepEqual(expect, res) {
+		t.Errorf("expected %v\ngot %v\n", expect, res)
+	}
+	if buf.Len() == 0 {
+		t.Error("expected trace.Start to have recorded data")
+	}
+}
+
+func TestRunConcWithTraceFlag(t *testing.T) {
+	p := &Program{Tasks: tasks(5), Trace: true}
+	res := p.RunConc(2)
+
+	expect := []interface{}{"task0", "task1", "task2", "task3", "task4"}
+	if !reflect.DeepEqual(expect, res) {
+		t.Errorf("expected %v\ngot %v\n", expect, res)
+	}
+}